@@ -0,0 +1,244 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/philippfranke/tweecli/ratelimit"
+)
+
+// Rule is a filtered stream rule, as managed via /2/tweets/search/stream/rules.
+type Rule struct {
+	ID    string `json:"id,omitempty"`
+	Value string `json:"value"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// StreamTweet is a single message delivered over the filtered stream.
+type StreamTweet struct {
+	Data     Tweet `json:"data"`
+	Includes struct {
+		Users []User `json:"users"`
+	} `json:"includes"`
+	Errors []struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// StreamClient manages rules for, and connects to, the v2 filtered stream
+// endpoint.
+type StreamClient struct {
+	// BearerToken authenticates every request.
+	BearerToken string
+
+	// HTTPClient is used to perform requests. If nil, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+
+	// BaseURL overrides BaseURL, mainly for tests.
+	BaseURL string
+}
+
+// NewStreamClient returns a StreamClient authenticating with bearerToken.
+func NewStreamClient(bearerToken string) *StreamClient {
+	return &StreamClient{BearerToken: bearerToken}
+}
+
+func (c *StreamClient) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *StreamClient) baseURL() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+	return BaseURL
+}
+
+func (c *StreamClient) do(method, endpoint string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, c.baseURL()+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("v2: StatusCode = %d, Body: %s", resp.StatusCode, string(b))
+	}
+	return b, nil
+}
+
+// Rules returns the rules currently attached to the stream.
+func (c *StreamClient) Rules() ([]Rule, error) {
+	b, err := c.do("GET", "/tweets/search/stream/rules", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Data []Rule `json:"data"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+// DeleteRules deletes the rules with the given ids.
+func (c *StreamClient) DeleteRules(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(struct {
+		Delete struct {
+			IDs []string `json:"ids"`
+		} `json:"delete"`
+	}{Delete: struct {
+		IDs []string `json:"ids"`
+	}{IDs: ids}})
+	if err != nil {
+		return err
+	}
+	_, err = c.do("POST", "/tweets/search/stream/rules", body)
+	return err
+}
+
+// AddRules adds rules to the stream.
+func (c *StreamClient) AddRules(rules []Rule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(struct {
+		Add []Rule `json:"add"`
+	}{Add: rules})
+	if err != nil {
+		return err
+	}
+	_, err = c.do("POST", "/tweets/search/stream/rules", body)
+	return err
+}
+
+// SetRules replaces the currently active rules with rules.
+func (c *StreamClient) SetRules(rules []Rule) error {
+	existing, err := c.Rules()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(existing))
+	for i, r := range existing {
+		ids[i] = r.ID
+	}
+	if err := c.DeleteRules(ids); err != nil {
+		return err
+	}
+	return c.AddRules(rules)
+}
+
+// Stream is an open connection to the filtered stream endpoint.
+type Stream struct {
+	resp *http.Response
+	dec  *json.Decoder
+}
+
+// Connect opens the filtered stream, requesting author_id expansions and
+// the given (or default) tweet/user fields. Rules must already be set via
+// SetRules or AddRules.
+func (c *StreamClient) Connect(tweetFields, userFields []string) (*Stream, error) {
+	if len(tweetFields) == 0 {
+		tweetFields = DefaultTweetFields
+	}
+	if len(userFields) == 0 {
+		userFields = DefaultUserFields
+	}
+
+	params := make(url.Values)
+	params.Set("expansions", "author_id")
+	params.Set("tweet.fields", joinFields(tweetFields))
+	params.Set("user.fields", joinFields(userFields))
+
+	u := c.baseURL() + "/tweets/search/stream?" + params.Encode()
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &StreamError{StatusCode: resp.StatusCode, Body: string(b), RateLimit: ratelimit.Parse(resp.Header)}
+	}
+
+	return &Stream{resp: resp, dec: json.NewDecoder(resp.Body)}, nil
+}
+
+// Next blocks until the next message is available. Keep-alive newlines
+// between messages are skipped by the JSON decoder and never returned;
+// a message with neither a tweet nor errors yields a nil StreamTweet,
+// which callers should ignore and call Next again. t.Errors may be non-empty alongside a
+// valid t.Data (e.g. a referenced tweet or author that couldn't be
+// hydrated); that is a partial error, not a reason to drop the tweet, so
+// it is only surfaced as an error when no tweet came with it.
+func (s *Stream) Next() (*StreamTweet, error) {
+	var t StreamTweet
+	if err := s.dec.Decode(&t); err != nil {
+		return nil, err
+	}
+	if t.Data.ID != "" {
+		return &t, nil
+	}
+	if len(t.Errors) > 0 {
+		return nil, fmt.Errorf("v2: %s: %s", t.Errors[0].Title, t.Errors[0].Detail)
+	}
+	return nil, nil
+}
+
+// Close closes the underlying connection, unblocking any in-flight Next.
+func (s *Stream) Close() error {
+	return s.resp.Body.Close()
+}
+
+// StreamError is returned by Connect when the stream endpoint refuses the
+// connection, e.g. with 420 (enhance your calm) or 429 (rate limited).
+type StreamError struct {
+	StatusCode int
+	Body       string
+	RateLimit  ratelimit.RateLimit
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("v2: StatusCode = %d, Body: %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,228 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+// Package v2 implements a minimal client for the Twitter API v2
+// tweet search endpoints (recent and full-archive).
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/philippfranke/tweecli/ratelimit"
+)
+
+// BaseURL is the root of the Twitter API v2.
+const BaseURL = "https://api.twitter.com/2"
+
+// DefaultTweetFields are requested when no tweet.fields are given explicitly.
+var DefaultTweetFields = []string{"created_at", "author_id", "lang", "public_metrics", "referenced_tweets", "entities", "geo"}
+
+// DefaultUserFields are requested when no user.fields are given explicitly.
+var DefaultUserFields = []string{"username", "name"}
+
+// PublicMetrics holds the engagement counts for a Tweet.
+type PublicMetrics struct {
+	RetweetCount int `json:"retweet_count"`
+	ReplyCount   int `json:"reply_count"`
+	LikeCount    int `json:"like_count"`
+	QuoteCount   int `json:"quote_count"`
+}
+
+// ReferencedTweet describes a tweet this Tweet refers to, e.g. a retweet
+// or a reply.
+type ReferencedTweet struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Entities holds the entities Twitter has extracted from a Tweet's text.
+type Entities struct {
+	Hashtags []struct {
+		Tag string `json:"tag"`
+	} `json:"hashtags,omitempty"`
+	Mentions []struct {
+		Username string `json:"username"`
+	} `json:"mentions,omitempty"`
+	URLs []struct {
+		ExpandedURL string `json:"expanded_url"`
+	} `json:"urls,omitempty"`
+}
+
+// Geo holds the place and coordinates a Tweet was sent from, if any.
+type Geo struct {
+	PlaceID     string `json:"place_id,omitempty"`
+	Coordinates *struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"coordinates,omitempty"`
+}
+
+// Tweet represents a single tweet as returned by the v2 search endpoints.
+type Tweet struct {
+	ID               string            `json:"id"`
+	Text             string            `json:"text"`
+	AuthorID         string            `json:"author_id,omitempty"`
+	CreatedAt        string            `json:"created_at,omitempty"`
+	Lang             string            `json:"lang,omitempty"`
+	PublicMetrics    *PublicMetrics    `json:"public_metrics,omitempty"`
+	ReferencedTweets []ReferencedTweet `json:"referenced_tweets,omitempty"`
+	Entities         *Entities         `json:"entities,omitempty"`
+	Geo              *Geo              `json:"geo,omitempty"`
+}
+
+// User represents the author of a Tweet, returned via expansions=author_id.
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name,omitempty"`
+}
+
+// SearchResponse is the decoded body of a v2 search response.
+type SearchResponse struct {
+	Data     []Tweet `json:"data"`
+	Includes struct {
+		Users []User `json:"users"`
+	} `json:"includes"`
+	Meta struct {
+		NextToken   string `json:"next_token"`
+		ResultCount int    `json:"result_count"`
+	} `json:"meta"`
+	Errors []struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// SearchClient talks to the v2 tweet search endpoints using bearer token
+// (app-only) authentication.
+type SearchClient struct {
+	// BearerToken authenticates every request.
+	BearerToken string
+
+	// HTTPClient is used to perform requests. If nil, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+
+	// BaseURL overrides BaseURL, mainly for tests.
+	BaseURL string
+}
+
+// NewSearchClient returns a SearchClient authenticating with bearerToken.
+func NewSearchClient(bearerToken string) *SearchClient {
+	return &SearchClient{BearerToken: bearerToken}
+}
+
+// BuildParams assembles the url.Values for a search request, requesting
+// author_id expansions and the given (or default) tweet/user fields.
+func BuildParams(query string, maxResults int, tweetFields, userFields []string, nextToken string) url.Values {
+	if len(tweetFields) == 0 {
+		tweetFields = DefaultTweetFields
+	}
+	if len(userFields) == 0 {
+		userFields = DefaultUserFields
+	}
+
+	params := make(url.Values)
+	params.Set("query", query)
+	params.Set("expansions", "author_id")
+	params.Set("tweet.fields", joinFields(tweetFields))
+	params.Set("user.fields", joinFields(userFields))
+	if maxResults > 0 {
+		params.Set("max_results", strconv.Itoa(maxResults))
+	}
+	if nextToken != "" {
+		params.Set("next_token", nextToken)
+	}
+	return params
+}
+
+func joinFields(fields []string) string {
+	s := ""
+	for i, f := range fields {
+		if i > 0 {
+			s += ","
+		}
+		s += f
+	}
+	return s
+}
+
+func (c *SearchClient) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *SearchClient) baseURL() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+	return BaseURL
+}
+
+// search performs a GET against the given endpoint (relative to baseURL)
+// with params, decoding the JSON body into a SearchResponse. The returned
+// RateLimit reflects the X-Rate-Limit-* headers of the response, whether
+// or not the request succeeded. On HTTP 429 the error is a
+// *ratelimit.Error.
+func (c *SearchClient) search(endpoint string, params url.Values) (*SearchResponse, ratelimit.RateLimit, error) {
+	u, err := url.Parse(c.baseURL() + endpoint)
+	if err != nil {
+		return nil, ratelimit.RateLimit{}, err
+	}
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, ratelimit.RateLimit{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, ratelimit.RateLimit{}, err
+	}
+	defer resp.Body.Close()
+
+	rl := ratelimit.Parse(resp.Header)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, rl, &ratelimit.Error{RateLimit: rl, StatusCode: resp.StatusCode}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, rl, fmt.Errorf("v2: StatusCode = %d, Body: %s", resp.StatusCode, string(b))
+	}
+
+	var result SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, rl, err
+	}
+	// result.Errors holds partial errors (e.g. a referenced tweet or
+	// author that couldn't be hydrated) that accompany a perfectly good
+	// page of result.Data; it is not a reason to discard the page or
+	// stall pagination, so it is not surfaced as an error here. Callers
+	// can inspect result.Errors themselves if they want to log it.
+	return &result, rl, nil
+}
+
+// SearchRecent searches tweets from the last 7 days. params is typically
+// built with BuildParams.
+func (c *SearchClient) SearchRecent(params url.Values) (*SearchResponse, ratelimit.RateLimit, error) {
+	return c.search("/tweets/search/recent", params)
+}
+
+// SearchAll searches the full archive. It requires academic research
+// access and is otherwise identical to SearchRecent.
+func (c *SearchClient) SearchAll(params url.Values) (*SearchResponse, ratelimit.RateLimit, error) {
+	return c.search("/tweets/search/all", params)
+}
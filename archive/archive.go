@@ -0,0 +1,116 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+// Package archive decodes tweets out of an official Twitter data export
+// ZIP, so they can be converted to the same output sinks as a live
+// search or stream.
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"time"
+)
+
+// DateLayout is the timestamp format used by the archive's created_at
+// field, e.g. "Wed Oct 10 20:19:24 -0700 2018".
+const DateLayout = "Mon Jan 02 15:04:05 -0700 2006"
+
+// tweetFile matches the files inside an archive that hold tweets, across
+// the export's naming history: tweet.js, tweets.js, tweet-part1.js,
+// tweets-part2.js, and so on.
+var tweetFile = regexp.MustCompile(`^tweets?(-part\d+)?\.js$`)
+
+// Tweet is a single entry decoded from the archive, using the archive's
+// own field names.
+type Tweet struct {
+	IDStr     string `json:"id_str"`
+	CreatedAt string `json:"created_at"`
+	FullText  string `json:"full_text"`
+	User      struct {
+		ScreenName string `json:"screen_name"`
+	} `json:"user"`
+}
+
+// Time parses t.CreatedAt using DateLayout.
+func (t Tweet) Time() (time.Time, error) {
+	return time.Parse(DateLayout, t.CreatedAt)
+}
+
+// ReadZip reads every tweet file inside the archive at path, across
+// however many tweet-partN.js files it was split into.
+func ReadZip(zipPath string) ([]Tweet, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var tweets []Tweet
+	for _, f := range r.File {
+		if !tweetFile.MatchString(path.Base(f.Name)) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := parseEntries(stripJSPrefix(b))
+		if err != nil {
+			return nil, err
+		}
+		tweets = append(tweets, entries...)
+	}
+	return tweets, nil
+}
+
+// stripJSPrefix removes the "window.YTD.tweet.part0 = " (or similar)
+// variable assignment the archive wraps its JSON arrays in, leaving
+// valid JSON.
+func stripJSPrefix(b []byte) []byte {
+	if i := bytes.IndexByte(b, '['); i >= 0 {
+		return b[i:]
+	}
+	return b
+}
+
+// parseEntries decodes a tweets.js JSON array. Newer exports wrap each
+// tweet as {"tweet": {...}}; older exports put the fields directly on
+// the array element. Both are handled.
+func parseEntries(data []byte) ([]Tweet, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	tweets := make([]Tweet, 0, len(raw))
+	for _, r := range raw {
+		var wrapper struct {
+			Tweet Tweet `json:"tweet"`
+		}
+		if err := json.Unmarshal(r, &wrapper); err == nil && wrapper.Tweet.IDStr != "" {
+			tweets = append(tweets, wrapper.Tweet)
+			continue
+		}
+
+		var t Tweet
+		if err := json.Unmarshal(r, &t); err != nil {
+			return nil, err
+		}
+		tweets = append(tweets, t)
+	}
+	return tweets, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/garyburd/go-oauth/oauth"
+
+	"github.com/philippfranke/tweecli/config"
+)
+
+// requestTokenURL, authorizeURL, and accessTokenURL are Twitter's OAuth1
+// PIN-based ("oob") authorization endpoints.
+const (
+	requestTokenURL = "https://api.twitter.com/oauth/request_token"
+	authorizeURL    = "https://api.twitter.com/oauth/authorize"
+	accessTokenURL  = "https://api.twitter.com/oauth/access_token"
+)
+
+// bearerTokenURL is Twitter's OAuth2 app-only token endpoint.
+const bearerTokenURL = "https://api.twitter.com/oauth2/token"
+
+// runAuth implements the "tweecli auth" subcommand: it walks the user
+// through the OAuth1 PIN flow and saves the resulting Token/TokenSecret
+// to the config file. It also exchanges the consumer key/secret for an
+// app-only bearer token and saves that too, since search and streaming
+// authenticate with BearerToken alone and would otherwise still need a
+// secret pasted on the command line every run.
+func runAuth(args []string) {
+	fs := flag.NewFlagSet("auth", flag.ExitOnError)
+	consumerKey := fs.String("consumer_key", "", "Consumer Key (falls back to TWEECLI_CONSUMER_KEY or the config file)")
+	consumerSecret := fs.String("consumer_secret", "", "Consumer Secret (falls back to TWEECLI_CONSUMER_SECRET or the config file)")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Couldn't load config: %v", err)
+	}
+	if *consumerKey != "" {
+		cfg.ConsumerKey = *consumerKey
+	}
+	if *consumerSecret != "" {
+		cfg.ConsumerSecret = *consumerSecret
+	}
+	if cfg.ConsumerKey == "" || cfg.ConsumerSecret == "" {
+		log.Fatal("consumer_key and consumer_secret are required (flag, TWEECLI_CONSUMER_KEY/SECRET, or config file)")
+	}
+
+	client := &oauth.Client{
+		TemporaryCredentialRequestURI: requestTokenURL,
+		ResourceOwnerAuthorizationURI: authorizeURL,
+		TokenRequestURI:               accessTokenURL,
+		Credentials: oauth.Credentials{
+			Token:  cfg.ConsumerKey,
+			Secret: cfg.ConsumerSecret,
+		},
+	}
+
+	tempCred, err := client.RequestTemporaryCredentials(nil, "oob", nil)
+	if err != nil {
+		log.Fatalf("Couldn't request temporary credentials: %v", err)
+	}
+
+	fmt.Println("Go to the following URL to authorize tweecli, then enter the PIN shown:")
+	fmt.Println(client.AuthorizationURL(tempCred, url.Values{}))
+	fmt.Print("PIN: ")
+
+	pin, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		log.Fatalf("Couldn't read PIN: %v", err)
+	}
+	pin = strings.TrimSpace(pin)
+
+	tokenCred, _, err := client.RequestToken(nil, tempCred, pin)
+	if err != nil {
+		log.Fatalf("Couldn't exchange PIN for an access token: %v", err)
+	}
+	cfg.Token = tokenCred.Token
+	cfg.TokenSecret = tokenCred.Secret
+
+	bearerToken, err := requestBearerToken(cfg.ConsumerKey, cfg.ConsumerSecret)
+	if err != nil {
+		log.Fatalf("Couldn't obtain a bearer token: %v", err)
+	}
+	cfg.BearerToken = bearerToken
+
+	if err := config.Save(cfg); err != nil {
+		log.Fatalf("Couldn't save config to %s: %v", config.Path(), err)
+	}
+
+	fmt.Printf("Saved credentials to %s\n", config.Path())
+}
+
+// requestBearerToken performs the OAuth2 client-credentials exchange
+// described at https://developer.twitter.com/en/docs/authentication/oauth-2-0/bearer-tokens.
+func requestBearerToken(consumerKey, consumerSecret string) (string, error) {
+	req, err := http.NewRequest("POST", bearerTokenURL, strings.NewReader("grant_type=client_credentials"))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(consumerKey, consumerSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("auth: StatusCode = %d, Body: %s", resp.StatusCode, string(b))
+	}
+
+	var body struct {
+		TokenType   string `json:"token_type"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return "", err
+	}
+	if body.TokenType != "bearer" || body.AccessToken == "" {
+		return "", fmt.Errorf("auth: unexpected response body: %s", string(b))
+	}
+
+	return body.AccessToken, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+// Package config loads tweecli's credentials from environment variables
+// and a ~/.tweecli.json file, in that precedence (callers apply flags on
+// top, so the final precedence is flags, then environment, then file).
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the credentials tweecli needs to talk to Twitter. Token
+// and TokenSecret are the user-context OAuth1 credentials produced by
+// "tweecli auth"; BearerToken is the app-only OAuth2 credential that
+// search and streaming actually authenticate with.
+type Config struct {
+	ConsumerKey    string `json:"consumer_key,omitempty"`
+	ConsumerSecret string `json:"consumer_secret,omitempty"`
+	Token          string `json:"token,omitempty"`
+	TokenSecret    string `json:"token_secret,omitempty"`
+	BearerToken    string `json:"bearer_token,omitempty"`
+}
+
+// Path returns the default config file location, ~/.tweecli.json.
+func Path() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".tweecli.json"
+	}
+	return filepath.Join(home, ".tweecli.json")
+}
+
+// Load reads the config file at Path (if any), then overlays any of the
+// TWEECLI_CONSUMER_KEY, TWEECLI_CONSUMER_SECRET, TWEECLI_TOKEN,
+// TWEECLI_TOKEN_SECRET, and TWEECLI_BEARER_TOKEN environment variables
+// that are set. A missing config file is not an error.
+func Load() (*Config, error) {
+	cfg := &Config{}
+
+	if b, err := ioutil.ReadFile(Path()); err == nil {
+		if err := json.Unmarshal(b, cfg); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if v := os.Getenv("TWEECLI_CONSUMER_KEY"); v != "" {
+		cfg.ConsumerKey = v
+	}
+	if v := os.Getenv("TWEECLI_CONSUMER_SECRET"); v != "" {
+		cfg.ConsumerSecret = v
+	}
+	if v := os.Getenv("TWEECLI_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	if v := os.Getenv("TWEECLI_TOKEN_SECRET"); v != "" {
+		cfg.TokenSecret = v
+	}
+	if v := os.Getenv("TWEECLI_BEARER_TOKEN"); v != "" {
+		cfg.BearerToken = v
+	}
+
+	return cfg, nil
+}
+
+// Save writes cfg to Path as JSON, readable only by the current user.
+func Save(cfg *Config) error {
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(Path(), b, 0600)
+}
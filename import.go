@@ -0,0 +1,70 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+	"time"
+
+	v2 "github.com/philippfranke/tweecli/api/v2"
+	"github.com/philippfranke/tweecli/archive"
+	"github.com/philippfranke/tweecli/output"
+)
+
+// runImport implements the "tweecli import" subcommand: it decodes the
+// tweets in a Twitter data export ZIP and feeds them through the same
+// Output sinks used for live search and streaming.
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "csv", "Output format: csv, jsonl, sqlite, or stdout")
+	outPath := fs.String("output", "output.csv", "Output file path (ignored for -format stdout)")
+	columns := fs.String("columns", "id,created_at,username,text", "Comma-separated columns to write (csv/stdout only)")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		log.Fatal("usage: tweecli import [flags] <archive.zip>")
+	}
+
+	tweets, err := archive.ReadZip(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Couldn't read archive: %v", err)
+	}
+
+	out, err := output.New(*format, *outPath, strings.Split(*columns, ","))
+	if err != nil {
+		log.Fatalf("Couldn't create output: %v", err)
+	}
+
+	batch := make([]output.Tweet, len(tweets))
+	for i, t := range tweets {
+		batch[i] = output.Tweet{
+			Tweet:          v2.Tweet{ID: t.IDStr, CreatedAt: archiveCreatedAt(t), Text: t.FullText},
+			AuthorUsername: t.User.ScreenName,
+		}
+	}
+
+	if err := out.Write(batch); err != nil {
+		log.Printf("Couldn't write tweets: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatalf("Couldn't close output: %v", err)
+	}
+
+	log.Printf("Imported %d tweets from %s", len(tweets), fs.Arg(0))
+}
+
+// archiveCreatedAt normalizes an archive tweet's created_at to RFC3339,
+// matching the format v2.Tweet.CreatedAt uses for live search and stream
+// results. If the archive timestamp can't be parsed, it's kept as-is.
+func archiveCreatedAt(t archive.Tweet) string {
+	parsed, err := t.Time()
+	if err != nil {
+		return t.CreatedAt
+	}
+	return parsed.UTC().Format(time.RFC3339)
+}
@@ -6,13 +6,8 @@
 package main
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"flag"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
@@ -20,180 +15,145 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/garyburd/go-oauth/oauth"
+	v2 "github.com/philippfranke/tweecli/api/v2"
+	"github.com/philippfranke/tweecli/config"
+	"github.com/philippfranke/tweecli/output"
+	"github.com/philippfranke/tweecli/ratelimit"
 )
 
 // Useful flags
 var (
-	query      = flag.String("q", "", "Search for tweets referencing the given q")
-	language   = flag.String("lang", "en", "Restricts tweets to the given lang")
-	until      = flag.String("until", "", "Restricts tweets sent before the given date. (YYYY-MM-DD)")
-	maxID      = flag.Int64("max_id", 0, "Restricts tweets with an ID less than or equal to the given ID")
-	sinceID    = flag.Int64("since_id", 0, "Restricts tweets with an ID greater than the given ID")
-	count      = flag.Int("count", 15, "Number of tweets returned per request")
-	resultType = flag.String("result_type", "mixed", "recent: only most recent tweets, popular: only most popular tweets, mixed: both")
-	token      = flag.String("token", "", "Consumer Key")
-	secret     = flag.String("secret", "", "Consumer Secret")
+	query    = flag.String("q", "", "Search for tweets referencing the given q")
+	language = flag.String("lang", "en", "Restricts tweets to the given lang")
+	until    = flag.String("until", "", "Restricts tweets sent before the given date. (YYYY-MM-DD)")
+	untilID  = flag.Int64("until_id", 0, "Restricts tweets with an ID less than or equal to the given ID")
+	sinceID  = flag.Int64("since_id", 0, "Restricts tweets with an ID greater than the given ID")
+	count    = flag.Int("count", 15, "Number of tweets returned per request")
+	full     = flag.Bool("full_archive", false, "Search the full archive instead of only the last 7 days (requires academic research access)")
+	stream   = flag.Bool("stream", false, "Use the filtered stream instead of search; -q/-lang become the stream rule")
+	bearer   = flag.String("bearer_token", "", "Twitter API v2 bearer token (falls back to TWEECLI_BEARER_TOKEN or the config file)")
+	format   = flag.String("format", "csv", "Output format: csv, jsonl, sqlite, or stdout")
+	outPath  = flag.String("output", "output.csv", "Output file path (ignored for -format stdout)")
+	columns  = flag.String("columns", "id,created_at,username,text", "Comma-separated columns to write (csv/stdout only): id, created_at, username, text, lang, retweet_count, reply_count, like_count, quote_count, referenced_tweets, entities, geo")
 )
 
-// oauth Client
-var authClient *oauth.Client
-
-// Mapping all available resultTypes
-var resultTypes = map[string]bool{
-	"mixed":   true,
-	"recent":  true,
-	"popular": true,
-}
-
-// Tweet represents a twitter post
-type tweet struct {
-	ID        int64  `json:"id"`
-	CreatedAt string `json:"created_at"`
-	User      user   `json:"user"`
-	Text      string `json:"text"`
-}
-
-// User represents a twitter user
-type user struct {
-	ScreenName string `json:"screen_name"`
-}
-
-// Result represents a search result
-type result struct {
-	Metadata metadata `json:"search_metadata"`
-	Statuses []tweet  `json:"statuses"`
-}
-
-// Metadata represents search metadata
-type metadata struct {
-	NextResult string `json:"next_results"`
-}
-
 func main() {
-	flag.Parse()
-
-	// Twitter Auth
-	authClient = &oauth.Client{
-		Credentials: oauth.Credentials{
-			Token:  *token,
-			Secret: *secret,
-		},
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "auth":
+			runAuth(os.Args[2:])
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		}
 	}
 
-	// Prepare twitter query
-	params := make(url.Values)
+	flag.Parse()
 
 	// Check Query
 	if *query == "" {
 		log.Fatal("q is required!")
 	}
 
-	if len(*query) > 500 {
+	if len(*query) > 1024 {
 		log.Fatalf("q has too many characters: %d", len(*query))
 	}
-	params.Set("q", *query)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Couldn't load config: %v", err)
+	}
+	if *bearer == "" {
+		*bearer = cfg.BearerToken
+	}
+	if *bearer == "" {
+		log.Fatal("bearer_token is required (flag, TWEECLI_BEARER_TOKEN, or config file)!")
+	}
 
 	// Check Language
 	if len(*language) != 0 && len(*language) != 2 {
 		log.Fatalf("lang has too many characters: %d; (ISO 639-1)", len(*language))
-	} else {
-		params.Set("lang", *language)
-	}
-
-	// Check until
-	if *until != "" && !correctDate(*until) {
-		log.Println("until couldn't be parsed! Ignore until")
-	} else {
-		params.Set("until", *until)
 	}
 
 	// Check count
-	if *count < 0 || *count > 100 {
-		log.Printf("Count is not between 0 and 100: %d! Use default: 15", *count)
+	if *count < 10 || *count > 100 {
+		log.Printf("Count is not between 10 and 100: %d! Use default: 15", *count)
 		*count = 15
 	}
-	params.Set("count", strconv.Itoa(*count))
 
-	// Check result_type
-	*resultType = strings.ToLower(*resultType)
-	if !resultTypes[*resultType] {
-		log.Printf("result_type is invalid: %s! Use default: mixed", *resultType)
-		*resultType = "mixed"
+	// Build the base v2 query from -q and -lang.
+	q := *query
+	if *language != "" {
+		q += " lang:" + *language
 	}
-	params.Set("result_type", *resultType)
 
-	// Check max_id
-	if *maxID != 0 {
-		params.Set("max_id", strconv.Itoa(int(*maxID)))
+	params := v2.BuildParams(q, *count, nil, nil, "")
+
+	// Check until
+	if *until != "" {
+		if t, err := time.Parse("2006-01-02", *until); err != nil {
+			log.Println("until couldn't be parsed! Ignore until")
+		} else {
+			params.Set("end_time", t.UTC().Format(time.RFC3339))
+		}
+	}
+
+	// Check until_id
+	if *untilID != 0 {
+		params.Set("until_id", strconv.FormatInt(*untilID, 10))
 	}
 
 	// Check since_id
 	if *sinceID != 0 {
-		params.Set("since_id", strconv.Itoa(int(*sinceID)))
+		params.Set("since_id", strconv.FormatInt(*sinceID, 10))
 	}
 
-	rawParam := params.Encode()
+	client := v2.NewSearchClient(*bearer)
+	search := client.SearchRecent
+	if *full {
+		search = client.SearchAll
+	}
+
+	out, err := output.New(*format, *outPath, strings.Split(*columns, ","))
+	if err != nil {
+		log.Fatalf("Couldn't create output: %v", err)
+	}
 
 	// Graceful stop
 	twitterStopChan := make(chan struct{}, 1)
-	csvStopChan := make(chan struct{}, 1)
+	outputStopChan := make(chan struct{}, 1)
 	stop := false
+	var collector streamCollector
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-signalChan
 		log.Println("Stopping...")
 		stop = true
+		collector.stop()
 	}()
 
 	// Chan for tweets
-	pages := make(chan []tweet)
+	pages := make(chan *v2.SearchResponse)
 
-	// CSV
+	// Output
 	go func() {
 		defer func() {
-			csvStopChan <- struct{}{}
+			outputStopChan <- struct{}{}
 		}()
 
-		file, err := os.Create("output.csv")
-		if err != nil {
-			log.Printf("Could create csv file: %v", err)
-			return
-		}
-		w := csv.NewWriter(file)
-
-		w.Write([]string{"ID", "Created at", "Screen Name", "Tweet"})
-
 		for page := range pages {
-			for _, tweet := range page {
-
-				r := []string{
-					strconv.Itoa(int(tweet.ID)),
-					tweet.CreatedAt,
-					tweet.User.ScreenName,
-					tweet.Text,
-				}
-
-				if err := w.Write(r); err != nil {
-					log.Printf("Couldn't write tweet: %v", err)
-					continue
-				}
-			}
-
-			w.Flush()
-
-			if err := w.Error(); err != nil {
-				log.Printf("Couldn't write: %v", err)
-				break
+			if err := out.Write(output.Flatten(page)); err != nil {
+				log.Printf("Couldn't write tweets: %v", err)
 			}
 		}
-		w.Flush()
-		if err := w.Error(); err != nil {
-			log.Printf("Couldn't write: %v", err)
+
+		if err := out.Close(); err != nil {
+			log.Printf("Couldn't close output: %v", err)
 		}
 
-		log.Println("Stopped writing to CSV.")
+		log.Println("Stopped writing output.")
 	}()
 
 	// Twitter
@@ -203,75 +163,60 @@ func main() {
 			log.Println("Stopped collecting tweets.")
 		}()
 
-		u, _ := url.Parse("https://api.twitter.com/1.1/search/tweets.json")
+		if *stream {
+			rules := buildRules(*query, *language)
+			collector.run(v2.NewStreamClient(*bearer), rules, pages, &stop)
+			return
+		}
 
+		nextToken := ""
 		for {
 			if stop {
 				return
 			}
 			time.Sleep(500 * time.Millisecond)
 
-			// Set query
-			u.RawQuery = rawParam
-
-			req, err := http.NewRequest("GET", u.String(), nil)
-			if err != nil {
-				log.Println("Couldn't create request:", err)
+			if nextToken != "" {
+				params.Set("next_token", nextToken)
 			}
-			// Add oauth header
-			req.Header.Set("Authorization", authClient.AuthorizationHeader(nil, "GET", u, nil))
 
-			resp, err := http.DefaultClient.Do(req)
+			result, rl, err := search(params)
 			if err != nil {
+				if rlErr, ok := err.(*ratelimit.Error); ok {
+					wait := rlErr.Wait()
+					log.Printf("Reached rate limit wait: %s", wait)
+					sleepInterruptible(wait, &stop)
+					continue
+				}
 				log.Println("Error getting response:", err)
 				continue
 			}
 
-			// Wait if limit is reached
-			if resp.StatusCode == 429 {
-				resetTime := resp.Header.Get("X-Rate-Limit-Reset")
-				sec, _ := strconv.ParseInt(resetTime, 10, 64)
-				wait := time.Since(time.Unix(sec, 0))
-				log.Printf("Reached rate limit wait: %s", wait)
-				time.Sleep(wait)
-				continue
+			if len(result.Errors) > 0 {
+				for _, e := range result.Errors {
+					log.Printf("Partial error: %s: %s", e.Title, e.Detail)
+				}
 			}
 
-			if resp.StatusCode != http.StatusOK {
-				b, _ := ioutil.ReadAll(resp.Body)
-				log.Printf("StatusCode = %d, Body: %s", resp.StatusCode, string(b))
-				continue
+			if len(result.Data) > 0 {
+				log.Printf("Collected %d tweets", len(result.Data))
+				pages <- result
 			}
 
-			d := json.NewDecoder(resp.Body)
-			var result result
-			if err := d.Decode(&result); err == nil {
-				if len(result.Statuses) > 0 {
-					log.Printf("Collected %d tweets", len(result.Statuses))
-					pages <- result.Statuses
-				}
-				if result.Metadata.NextResult != "" {
-					// Set new query with maxid
-					rawParam = result.Metadata.NextResult[1:]
-				} else {
-					break
-				}
-			} else {
+			if result.Meta.NextToken == "" {
 				break
 			}
+
+			if rl.Exhausted() {
+				wait := rl.Wait()
+				log.Printf("Rate limit window exhausted, pausing for %s", wait)
+				sleepInterruptible(wait, &stop)
+			}
+			nextToken = result.Meta.NextToken
 		}
 	}()
 
 	<-twitterStopChan
 	close(pages)
-	<-csvStopChan
-}
-
-// correctDate checks if given str is formatted as YYYY-MM-DD and valid.
-func correctDate(str string) bool {
-	_, err := time.Parse("2006-01-02", str)
-	if err != nil {
-		return false
-	}
-	return true
+	<-outputStopChan
 }
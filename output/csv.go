@@ -0,0 +1,111 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CSV writes tweets as comma-separated values, one file header followed
+// by one row per tweet.
+type CSV struct {
+	w    *csv.Writer
+	f    io.Closer
+	cols []string
+}
+
+// NewCSV creates (or truncates) the file at path and writes the header
+// row for cols.
+func NewCSV(path string, cols []string) (*CSV, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := csv.NewWriter(file)
+	if err := w.Write(cols); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &CSV{w: w, f: file, cols: cols}, nil
+}
+
+// Write implements Output.
+func (c *CSV) Write(tweets []Tweet) error {
+	for _, t := range tweets {
+		if err := c.w.Write(csvRow(t, c.cols)); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Close implements Output.
+func (c *CSV) Close() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return err
+	}
+	return c.f.Close()
+}
+
+// csvRow renders the requested columns for t.
+func csvRow(t Tweet, cols []string) []string {
+	r := make([]string, len(cols))
+	for i, col := range cols {
+		switch strings.TrimSpace(col) {
+		case "id":
+			r[i] = t.ID
+		case "created_at":
+			r[i] = t.CreatedAt
+		case "username":
+			r[i] = t.AuthorUsername
+		case "text":
+			r[i] = t.Text
+		case "lang":
+			r[i] = t.Lang
+		case "retweet_count":
+			if t.PublicMetrics != nil {
+				r[i] = strconv.Itoa(t.PublicMetrics.RetweetCount)
+			}
+		case "reply_count":
+			if t.PublicMetrics != nil {
+				r[i] = strconv.Itoa(t.PublicMetrics.ReplyCount)
+			}
+		case "like_count":
+			if t.PublicMetrics != nil {
+				r[i] = strconv.Itoa(t.PublicMetrics.LikeCount)
+			}
+		case "quote_count":
+			if t.PublicMetrics != nil {
+				r[i] = strconv.Itoa(t.PublicMetrics.QuoteCount)
+			}
+		case "referenced_tweets":
+			if len(t.ReferencedTweets) > 0 {
+				b, _ := json.Marshal(t.ReferencedTweets)
+				r[i] = string(b)
+			}
+		case "entities":
+			if t.Entities != nil {
+				b, _ := json.Marshal(t.Entities)
+				r[i] = string(b)
+			}
+		case "geo":
+			if t.Geo != nil {
+				b, _ := json.Marshal(t.Geo)
+				r[i] = string(b)
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,43 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// JSONL writes one raw tweet object per line, so downstream pipelines can
+// consume the full tweet model without parsing CSV.
+type JSONL struct {
+	enc *json.Encoder
+	f   io.Closer
+}
+
+// NewJSONL creates (or truncates) the file at path.
+func NewJSONL(path string) (*JSONL, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONL{enc: json.NewEncoder(file), f: file}, nil
+}
+
+// Write implements Output.
+func (j *JSONL) Write(tweets []Tweet) error {
+	for _, t := range tweets {
+		if err := j.enc.Encode(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements Output.
+func (j *JSONL) Close() error {
+	return j.f.Close()
+}
@@ -0,0 +1,63 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+// Package output implements the sinks tweecli can write collected tweets
+// to: CSV, JSONL, SQLite, and stdout.
+package output
+
+import (
+	"fmt"
+
+	v2 "github.com/philippfranke/tweecli/api/v2"
+)
+
+// Tweet is a v2.Tweet enriched with the author's screen name, which sinks
+// need but which v2 only returns separately via expansions.
+type Tweet struct {
+	v2.Tweet
+	AuthorUsername string `json:"author_username,omitempty"`
+}
+
+// Output is a destination for collected tweets.
+type Output interface {
+	// Write persists a batch of tweets.
+	Write([]Tweet) error
+
+	// Close flushes and releases any resources held by the Output.
+	Close() error
+}
+
+// New returns the Output for the given format, writing to path.
+// format is one of "csv", "jsonl", "sqlite", or "stdout". columns is only
+// used by the csv and stdout formats.
+func New(format, path string, columns []string) (Output, error) {
+	switch format {
+	case "csv":
+		return NewCSV(path, columns)
+	case "jsonl":
+		return NewJSONL(path)
+	case "sqlite":
+		return NewSQLite(path)
+	case "stdout":
+		return NewStdout(columns)
+	default:
+		return nil, fmt.Errorf("output: unknown format %q", format)
+	}
+}
+
+// Flatten joins a v2.SearchResponse's tweets with their authors from
+// Includes.Users, producing the Tweets sinks consume.
+func Flatten(result *v2.SearchResponse) []Tweet {
+	users := make(map[string]v2.User, len(result.Includes.Users))
+	for _, u := range result.Includes.Users {
+		users[u.ID] = u
+	}
+
+	tweets := make([]Tweet, len(result.Data))
+	for i, t := range result.Data {
+		tweets[i] = Tweet{Tweet: t, AuthorUsername: users[t.AuthorID].Username}
+	}
+	return tweets
+}
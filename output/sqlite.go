@@ -0,0 +1,98 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package output
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tweets (
+	id TEXT PRIMARY KEY,
+	author_id TEXT,
+	author_username TEXT,
+	created_at TEXT,
+	lang TEXT,
+	text TEXT,
+	retweet_count INTEGER,
+	reply_count INTEGER,
+	like_count INTEGER,
+	quote_count INTEGER,
+	referenced_tweets TEXT,
+	entities TEXT,
+	geo TEXT
+);`
+
+// SQLite persists tweets to a local SQLite database, keyed on tweet ID so
+// that re-running a collection over the same time range is idempotent.
+type SQLite struct {
+	db     *sql.DB
+	insert *sql.Stmt
+}
+
+// NewSQLite opens (or creates) the database at path and prepares its
+// tweets table.
+func NewSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	stmt, err := db.Prepare(`INSERT OR IGNORE INTO tweets (
+		id, author_id, author_username, created_at, lang, text,
+		retweet_count, reply_count, like_count, quote_count,
+		referenced_tweets, entities, geo
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLite{db: db, insert: stmt}, nil
+}
+
+// Write implements Output.
+func (s *SQLite) Write(tweets []Tweet) error {
+	for _, t := range tweets {
+		var retweets, replies, likes, quotes int
+		if t.PublicMetrics != nil {
+			retweets = t.PublicMetrics.RetweetCount
+			replies = t.PublicMetrics.ReplyCount
+			likes = t.PublicMetrics.LikeCount
+			quotes = t.PublicMetrics.QuoteCount
+		}
+
+		referencedTweets, _ := json.Marshal(t.ReferencedTweets)
+		entities, _ := json.Marshal(t.Entities)
+		geo, _ := json.Marshal(t.Geo)
+
+		if _, err := s.insert.Exec(
+			t.ID, t.AuthorID, t.AuthorUsername, t.CreatedAt, t.Lang, t.Text,
+			retweets, replies, likes, quotes,
+			string(referencedTweets), string(entities), string(geo),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements Output.
+func (s *SQLite) Close() error {
+	if err := s.insert.Close(); err != nil {
+		s.db.Close()
+		return err
+	}
+	return s.db.Close()
+}
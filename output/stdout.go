@@ -0,0 +1,44 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package output
+
+import (
+	"encoding/csv"
+	"os"
+)
+
+// Stdout writes tweets as CSV to standard out, for piping into other
+// tools without leaving an output file behind.
+type Stdout struct {
+	w    *csv.Writer
+	cols []string
+}
+
+// NewStdout writes the header row for cols to stdout.
+func NewStdout(cols []string) (*Stdout, error) {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(cols); err != nil {
+		return nil, err
+	}
+	return &Stdout{w: w, cols: cols}, nil
+}
+
+// Write implements Output.
+func (s *Stdout) Write(tweets []Tweet) error {
+	for _, t := range tweets {
+		if err := s.w.Write(csvRow(t, s.cols)); err != nil {
+			return err
+		}
+	}
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// Close implements Output.
+func (s *Stdout) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
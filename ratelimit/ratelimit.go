@@ -0,0 +1,79 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+// Package ratelimit parses Twitter's X-Rate-Limit-* response headers and
+// computes how long to wait before the next request.
+package ratelimit
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit describes the state of a rate limit window, as reported by
+// the X-Rate-Limit-Limit, X-Rate-Limit-Remaining, and X-Rate-Limit-Reset
+// response headers.
+type RateLimit struct {
+	// Limit is the number of requests allowed in the current window.
+	Limit int
+
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+
+	// Reset is when the current window resets and Remaining returns to
+	// Limit.
+	Reset time.Time
+}
+
+// Parse reads the X-Rate-Limit-* headers from h. Missing or unparsable
+// headers are left at their zero value.
+func Parse(h http.Header) RateLimit {
+	limit, _ := strconv.Atoi(h.Get("X-Rate-Limit-Limit"))
+	remaining, _ := strconv.Atoi(h.Get("X-Rate-Limit-Remaining"))
+	sec, _ := strconv.ParseInt(h.Get("X-Rate-Limit-Reset"), 10, 64)
+
+	var reset time.Time
+	if sec != 0 {
+		reset = time.Unix(sec, 0)
+	}
+
+	return RateLimit{Limit: limit, Remaining: remaining, Reset: reset}
+}
+
+// Exhausted reports whether the current window has no requests left.
+func (rl RateLimit) Exhausted() bool {
+	return rl.Limit > 0 && rl.Remaining <= 0
+}
+
+// Wait returns how long to sleep until Reset, plus a small jitter so
+// multiple callers waking on the same window don't all retry at once.
+// It is never negative.
+func (rl RateLimit) Wait() time.Duration {
+	return WaitUntil(rl.Reset)
+}
+
+// WaitUntil returns time.Until(reset), floored at zero, plus up to 10%
+// jitter so simultaneous callers don't retry in lockstep.
+func WaitUntil(reset time.Time) time.Duration {
+	wait := time.Until(reset)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait + time.Duration(rand.Int63n(int64(wait)/10+1))
+}
+
+// Error is returned when a request is rejected with HTTP 429, carrying
+// the rate limit state from the response.
+type Error struct {
+	RateLimit
+	StatusCode int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ratelimit: StatusCode = %d, remaining = %d, reset = %s", e.StatusCode, e.Remaining, e.Reset)
+}
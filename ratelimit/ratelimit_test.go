@@ -0,0 +1,60 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	h := http.Header{}
+	h.Set("X-Rate-Limit-Limit", "450")
+	h.Set("X-Rate-Limit-Remaining", "0")
+	h.Set("X-Rate-Limit-Reset", strconv.FormatInt(reset, 10))
+
+	rl := Parse(h)
+	if rl.Limit != 450 {
+		t.Errorf("Limit = %d, want 450", rl.Limit)
+	}
+	if rl.Remaining != 0 {
+		t.Errorf("Remaining = %d, want 0", rl.Remaining)
+	}
+	if rl.Reset.Unix() != reset {
+		t.Errorf("Reset = %v, want %v", rl.Reset.Unix(), reset)
+	}
+	if !rl.Exhausted() {
+		t.Error("Exhausted() = false, want true")
+	}
+}
+
+func TestParseMissingHeaders(t *testing.T) {
+	rl := Parse(http.Header{})
+	if rl.Exhausted() {
+		t.Error("Exhausted() = true for a response with no rate limit headers, want false")
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	// A reset in the past must never produce a negative wait.
+	past := time.Now().Add(-time.Minute)
+	if wait := WaitUntil(past); wait < 0 {
+		t.Errorf("WaitUntil(past) = %s, want >= 0", wait)
+	}
+
+	// A reset in the future should wait at least that long, plus jitter.
+	future := time.Now().Add(time.Minute)
+	wait := WaitUntil(future)
+	if wait < time.Until(future) {
+		t.Errorf("WaitUntil(future) = %s, want >= %s", wait, time.Until(future))
+	}
+	if wait > time.Until(future)+time.Until(future)/10+time.Second {
+		t.Errorf("WaitUntil(future) = %s, want <= time until reset plus 10%% jitter", wait)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 Philipp Franke. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+//
+
+package main
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	v2 "github.com/philippfranke/tweecli/api/v2"
+)
+
+// maxBackoff caps the exponential reconnect backoff.
+const maxBackoff = 5 * time.Minute
+
+// streamCollector holds the currently open stream so a SIGINT can close it
+// and unblock the collecting goroutine immediately.
+type streamCollector struct {
+	mu     sync.Mutex
+	stream *v2.Stream
+}
+
+// stop closes the currently open stream, if any.
+func (sc *streamCollector) stop() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.stream != nil {
+		sc.stream.Close()
+	}
+}
+
+// buildRules derives filtered-stream rules from -q and -lang.
+func buildRules(query, lang string) []v2.Rule {
+	value := query
+	if lang != "" {
+		value += " lang:" + lang
+	}
+	return []v2.Rule{{Value: value, Tag: "tweecli"}}
+}
+
+// run connects to the filtered stream and pushes decoded tweets to pages
+// until stopped is true, reconnecting with exponential backoff on network
+// drops, 420s, and 429s.
+func (sc *streamCollector) run(client *v2.StreamClient, rules []v2.Rule, pages chan<- *v2.SearchResponse, stopped *bool) {
+	if err := client.SetRules(rules); err != nil {
+		log.Printf("Couldn't set stream rules: %v", err)
+		return
+	}
+
+	backoff := time.Second
+	for !*stopped {
+		stream, err := client.Connect(nil, nil)
+		if err != nil {
+			wait := backoffFor(err, backoff)
+			log.Printf("Couldn't connect to stream: %v; reconnecting in %s", err, wait)
+			time.Sleep(wait)
+			backoff = nextBackoff(backoff)
+			continue
+		}
+
+		sc.mu.Lock()
+		sc.stream = stream
+		sc.mu.Unlock()
+		backoff = time.Second
+
+		for !*stopped {
+			t, err := stream.Next()
+			if err != nil {
+				if !*stopped {
+					wait := backoffFor(err, backoff)
+					log.Printf("Stream read error: %v; reconnecting in %s", err, wait)
+					time.Sleep(wait)
+					backoff = nextBackoff(backoff)
+				}
+				break
+			}
+			if t == nil {
+				continue
+			}
+			if len(t.Errors) > 0 {
+				for _, e := range t.Errors {
+					log.Printf("Partial error: %s: %s", e.Title, e.Detail)
+				}
+			}
+
+			pages <- &v2.SearchResponse{
+				Data:     []v2.Tweet{t.Data},
+				Includes: t.Includes,
+			}
+		}
+
+		stream.Close()
+	}
+}
+
+// backoffFor returns how long to wait before reconnecting after err. On
+// 429 it honors the reset time from the X-Rate-Limit-Reset header, if
+// present; on 420 (enhance your calm) or any other error it uses the
+// given backoff. Both are padded with jitter.
+func backoffFor(err error, backoff time.Duration) time.Duration {
+	se, ok := err.(*v2.StreamError)
+	if !ok {
+		return backoff + jitter(backoff)
+	}
+	if se.StatusCode == 429 && !se.RateLimit.Reset.IsZero() {
+		return se.RateLimit.Wait()
+	}
+	return backoff + jitter(backoff)
+}
+
+func jitter(wait time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(wait)/4 + 1))
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// sleepTick bounds how long sleepInterruptible sleeps between checks of
+// stopped.
+const sleepTick = 200 * time.Millisecond
+
+// sleepInterruptible sleeps for d, checking stopped every sleepTick so a
+// long wait (e.g. a multi-minute rate-limit reset in search mode) can be
+// cut short by Ctrl-C instead of appearing to hang.
+func sleepInterruptible(d time.Duration, stopped *bool) {
+	for d > 0 && !*stopped {
+		tick := sleepTick
+		if d < tick {
+			tick = d
+		}
+		time.Sleep(tick)
+		d -= tick
+	}
+}